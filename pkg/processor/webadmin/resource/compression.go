@@ -0,0 +1,131 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// compressorPools holds one sync.Pool of reusable writers per supported Content-Encoding, so
+// compressing a response doesn't allocate a new writer (and its window/dictionary) per request
+var compressorPools = map[string]*sync.Pool{
+	"gzip": {
+		New: func() interface{} {
+			return gzip.NewWriter(ioutil.Discard)
+		},
+	},
+	"deflate": {
+		New: func() interface{} {
+			writer, _ := flate.NewWriter(ioutil.Discard, flate.DefaultCompression)
+			return writer
+		},
+	},
+}
+
+// compressingWriter is a pooled compressor (gzip or flate writer) that Reset can point at a new
+// underlying io.Writer
+type compressingWriter interface {
+	io.WriteCloser
+	Reset(io.Writer)
+}
+
+// acquireCompressor takes a writer for encoding from its pool, resets it onto the underlying
+// io.Writer and returns it, along with the function that returns it to its pool
+func acquireCompressor(encoding string, underlying io.Writer) (compressingWriter, func()) {
+	pool := compressorPools[encoding]
+
+	writer := pool.Get().(compressingWriter)
+	writer.Reset(underlying)
+
+	return writer, func() { pool.Put(writer) }
+}
+
+// preferredEncoding returns the first of "gzip" / "deflate" present in the Accept-Encoding
+// header, or "" if the client didn't ask for compression (or asked for one we don't support)
+func preferredEncoding(request *http.Request) string {
+	acceptEncoding := request.Header.Get("Accept-Encoding")
+
+	for _, candidate := range []string{"gzip", "deflate"} {
+		if strings.Contains(acceptEncoding, candidate) {
+			return candidate
+		}
+	}
+
+	return ""
+}
+
+// compressedResponseWriter wraps an http.ResponseWriter, transparently compressing everything
+// written to it with a pooled compressor
+type compressedResponseWriter struct {
+	http.ResponseWriter
+	compressor compressingWriter
+}
+
+func (crw *compressedResponseWriter) Write(data []byte) (int, error) {
+	return crw.compressor.Write(data)
+}
+
+// Flush lets a compressed response still be used for streaming (SSE): it flushes the pending
+// compressed bytes out of the compressor before flushing the underlying connection
+func (crw *compressedResponseWriter) Flush() {
+	if flusher, ok := crw.compressor.(flusher); ok {
+		flusher.Flush()
+	}
+
+	if flusher, ok := crw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+type flusher interface {
+	Flush() error
+}
+
+// compressionFilter is a chi middleware that, when the client advertises support for gzip or
+// deflate via Accept-Encoding, transparently compresses the response body
+func compressionFilter(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		encoding := preferredEncoding(request)
+
+		// compressedResponseWriter embeds http.ResponseWriter as an interface field, so it
+		// doesn't promote Hijack() even when the underlying writer supports it. A websocket
+		// upgrade needs to hijack the connection, so let it straight through uncompressed rather
+		// than silently failing the upgrade
+		if encoding == "" || isWebsocketUpgrade(request) {
+			next.ServeHTTP(responseWriter, request)
+			return
+		}
+
+		compressor, release := acquireCompressor(encoding, responseWriter)
+		defer release()
+		defer compressor.Close()
+
+		responseWriter.Header().Set("Content-Encoding", encoding)
+		responseWriter.Header().Add("Vary", "Accept-Encoding")
+
+		next.ServeHTTP(&compressedResponseWriter{
+			ResponseWriter: responseWriter,
+			compressor:     compressor,
+		}, request)
+	})
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// ConsulConfigProvider reads resource configuration from Consul's KV store, below a configured
+// prefix laid out as:
+//
+//	<prefix>/<name>/methods                 = "getList,getDetail"
+//	<prefix>/<name>/policy/auth              = "true"
+//	<prefix>/<name>/policy/rateLimitPerSecond = "10"
+//	<prefix>/<name>/policy/timeout            = "5s"
+//
+// Watch uses Consul's blocking queries to long-poll the prefix, so changes made to the KV tree
+// are picked up without the caller having to re-poll
+type ConsulConfigProvider struct {
+	client *consulapi.Client
+	prefix string
+}
+
+// NewConsulConfigProvider returns a ConsulConfigProvider reading resource configuration from
+// Consul's KV store below prefix
+func NewConsulConfigProvider(client *consulapi.Client, prefix string) *ConsulConfigProvider {
+	return &ConsulConfigProvider{
+		client: client,
+		prefix: strings.TrimSuffix(prefix, "/"),
+	}
+}
+
+// Load lists every key below the provider's prefix and groups them into a ResourceConfig per name
+func (p *ConsulConfigProvider) Load() ([]ResourceConfig, error) {
+	configs, _, err := p.load(nil)
+	return configs, err
+}
+
+func (p *ConsulConfigProvider) load(queryOptions *consulapi.QueryOptions) ([]ResourceConfig, *consulapi.QueryMeta, error) {
+	pairs, meta, err := p.client.KV().List(p.prefix, queryOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byName := map[string]*fileResourceConfig{}
+	var order []string
+
+	for _, pair := range pairs {
+		relative := strings.TrimPrefix(strings.TrimPrefix(pair.Key, p.prefix), "/")
+		if relative == "" {
+			continue
+		}
+
+		parts := strings.SplitN(relative, "/", 2)
+		name, field := parts[0], ""
+		if len(parts) == 2 {
+			field = parts[1]
+		}
+
+		fileConfig, ok := byName[name]
+		if !ok {
+			fileConfig = &fileResourceConfig{Name: name}
+			byName[name] = fileConfig
+			order = append(order, name)
+		}
+
+		value := string(pair.Value)
+
+		switch field {
+		case "methods":
+			fileConfig.Methods = strings.Split(value, ",")
+		case "policy/auth":
+			fileConfig.Policy.Auth = value == "true"
+		case "policy/rateLimitPerSecond":
+			rateLimit, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, nil, err
+			}
+			fileConfig.Policy.RateLimit = rateLimit
+		case "policy/timeout":
+			fileConfig.Policy.TimeoutString = value
+		}
+	}
+
+	configs := make([]ResourceConfig, 0, len(order))
+
+	for _, name := range order {
+		config, err := byName[name].toResourceConfig()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		configs = append(configs, config)
+	}
+
+	return configs, meta, nil
+}
+
+// Watch long-polls the KV prefix using Consul blocking queries, calling onChange with the freshly
+// loaded configuration every time Consul reports the tree's ModifyIndex has advanced. The returned
+// stop function ends the polling goroutine
+func (p *ConsulConfigProvider) Watch(onChange func([]ResourceConfig)) (func(), error) {
+	_, meta, err := p.load(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		lastIndex := meta.LastIndex
+
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			configs, meta, err := p.load(&consulapi.QueryOptions{
+				WaitIndex: lastIndex,
+				WaitTime:  time.Minute,
+			})
+			if err != nil {
+				// transient Consul/network error - back off briefly and retry rather than
+				// spinning the blocking query in a tight loop
+				time.Sleep(time.Second)
+				continue
+			}
+
+			if meta.LastIndex == lastIndex {
+				continue
+			}
+
+			lastIndex = meta.LastIndex
+			onChange(configs)
+		}
+	}()
+
+	return func() { close(done) }, nil
+}
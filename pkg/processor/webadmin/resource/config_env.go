@@ -0,0 +1,171 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envConfigPrefix is the prefix every resource configuration env var starts with
+const envConfigPrefix = "NUCLIO_RESOURCE_"
+
+// envConfigSuffixes are the recognized NUCLIO_RESOURCE_<NAME>_<SUFFIX> suffixes, ordered longest
+// first so a multi-word suffix like "_RATE_LIMIT" is matched whole rather than split on its own
+// internal underscore
+var envConfigSuffixes = []string{"_RATE_LIMIT", "_METHODS", "_TIMEOUT", "_AUTH"}
+
+// EnvConfigProvider reads resource configuration from environment variables, using the scheme:
+//
+//	NUCLIO_RESOURCE_<NAME>_METHODS=getList,getDetail   (required - comma separated method names,
+//	                                                     see resourceMethodNames for valid values)
+//	NUCLIO_RESOURCE_<NAME>_AUTH=true                   (optional, default false)
+//	NUCLIO_RESOURCE_<NAME>_RATE_LIMIT=10                (optional requests/second, default unlimited)
+//	NUCLIO_RESOURCE_<NAME>_TIMEOUT=5s                  (optional, parsed with time.ParseDuration)
+//
+// <NAME> is the resource's name upper-cased (e.g. "foo" -> NUCLIO_RESOURCE_FOO_METHODS). Since
+// environment variables are fixed for the process lifetime, Watch never reports a change
+type EnvConfigProvider struct {
+	environ func() []string
+}
+
+// NewEnvConfigProvider returns an EnvConfigProvider reading from the process environment
+func NewEnvConfigProvider() *EnvConfigProvider {
+	return &EnvConfigProvider{environ: os.Environ}
+}
+
+// Load parses every NUCLIO_RESOURCE_<NAME>_* variable currently set into a ResourceConfig
+func (p *EnvConfigProvider) Load() ([]ResourceConfig, error) {
+	env := map[string]string{}
+	namesSeen := map[string]bool{}
+
+	for _, entry := range p.environ() {
+		parts := strings.SplitN(entry, "=", 2)
+		key := parts[0]
+
+		if !strings.HasPrefix(key, envConfigPrefix) {
+			continue
+		}
+
+		if len(parts) == 2 {
+			env[key] = parts[1]
+		}
+
+		rest := strings.TrimPrefix(key, envConfigPrefix)
+
+		name, ok := trimEnvConfigSuffix(rest)
+		if !ok {
+			continue
+		}
+
+		namesSeen[strings.ToLower(name)] = true
+	}
+
+	names := make([]string, 0, len(namesSeen))
+	for name := range namesSeen {
+		names = append(names, name)
+	}
+
+	// deterministic order: config derived from a map should not depend on map iteration order
+	sort.Strings(names)
+
+	configs := make([]ResourceConfig, 0, len(names))
+
+	for _, name := range names {
+		config, err := loadResourceConfig(env, name)
+		if err != nil {
+			return nil, err
+		}
+
+		configs = append(configs, config)
+	}
+
+	return configs, nil
+}
+
+// trimEnvConfigSuffix strips a known NUCLIO_RESOURCE_<NAME>_<SUFFIX> suffix from rest and returns
+// the remaining <NAME>, or ok=false if rest doesn't end with a recognized suffix
+func trimEnvConfigSuffix(rest string) (string, bool) {
+	for _, suffix := range envConfigSuffixes {
+		if name := strings.TrimSuffix(rest, suffix); name != rest && name != "" {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// loadResourceConfig builds name's ResourceConfig by reading from env, the snapshot of
+// NUCLIO_RESOURCE_* variables Load took from p.environ() - never from the live process
+// environment, so a caller supplying a fake environ (as tests do) sees consistent results
+func loadResourceConfig(env map[string]string, name string) (ResourceConfig, error) {
+	upperName := strings.ToUpper(name)
+
+	methodNames := strings.Split(env[envConfigPrefix+upperName+"_METHODS"], ",")
+	resourceMethods := make([]resourceMethod, 0, len(methodNames))
+
+	for _, methodName := range methodNames {
+		methodName = strings.TrimSpace(methodName)
+		if methodName == "" {
+			continue
+		}
+
+		method, err := parseResourceMethod(methodName)
+		if err != nil {
+			return ResourceConfig{}, err
+		}
+
+		resourceMethods = append(resourceMethods, method)
+	}
+
+	policy := RoutePolicy{
+		RequireAuth: env[envConfigPrefix+upperName+"_AUTH"] == "true",
+	}
+
+	if rateLimit := env[envConfigPrefix+upperName+"_RATE_LIMIT"]; rateLimit != "" {
+		parsed, err := strconv.ParseFloat(rateLimit, 64)
+		if err != nil {
+			return ResourceConfig{}, err
+		}
+
+		policy.RateLimitPerSecond = parsed
+	}
+
+	if timeout := env[envConfigPrefix+upperName+"_TIMEOUT"]; timeout != "" {
+		parsed, err := time.ParseDuration(timeout)
+		if err != nil {
+			return ResourceConfig{}, err
+		}
+
+		policy.Timeout = parsed
+	}
+
+	return ResourceConfig{
+		Name:            name,
+		ResourceMethods: resourceMethods,
+		Policy:          policy,
+	}, nil
+}
+
+// Watch is a no-op: environment variables don't change for the lifetime of the process, so there
+// is nothing to subscribe to. The returned stop function is a no-op too
+func (p *EnvConfigProvider) Watch(onChange func([]ResourceConfig)) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// fileResourceConfig is the on-disk shape of a single resource's configuration file, in either
+// YAML (*.yaml, *.yml) or Java-style properties (*.properties) form
+type fileResourceConfig struct {
+	Name    string   `yaml:"name"`
+	Methods []string `yaml:"methods"`
+	Policy  struct {
+		Auth          bool    `yaml:"auth"`
+		RateLimit     float64 `yaml:"rateLimitPerSecond"`
+		TimeoutString string  `yaml:"timeout"`
+	} `yaml:"policy"`
+}
+
+// FileConfigProvider reads one resource configuration per file (*.yaml, *.yml or *.properties)
+// from a directory, and watches that directory with fsnotify so callers can react to files being
+// added, removed or edited without restarting
+type FileConfigProvider struct {
+	dir string
+}
+
+// NewFileConfigProvider returns a FileConfigProvider reading *.yaml/*.yml/*.properties files from dir
+func NewFileConfigProvider(dir string) *FileConfigProvider {
+	return &FileConfigProvider{dir: dir}
+}
+
+// Load reads every resource configuration file in the provider's directory
+func (p *FileConfigProvider) Load() ([]ResourceConfig, error) {
+	entries, err := ioutil.ReadDir(p.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []ResourceConfig
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		config, ok, err := p.loadFile(filepath.Join(p.dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			configs = append(configs, config)
+		}
+	}
+
+	return configs, nil
+}
+
+func (p *FileConfigProvider) loadFile(path string) (ResourceConfig, bool, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ResourceConfig{}, false, err
+	}
+
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		fileConfig, err := parseYAMLResourceConfig(contents)
+		if err != nil {
+			return ResourceConfig{}, false, err
+		}
+
+		config, err := fileConfig.toResourceConfig()
+		return config, true, err
+
+	case ".properties":
+		fileConfig, err := parsePropertiesResourceConfig(contents)
+		if err != nil {
+			return ResourceConfig{}, false, err
+		}
+
+		config, err := fileConfig.toResourceConfig()
+		return config, true, err
+
+	default:
+		return ResourceConfig{}, false, nil
+	}
+}
+
+func parseYAMLResourceConfig(contents []byte) (*fileResourceConfig, error) {
+	fileConfig := &fileResourceConfig{}
+	if err := yaml.Unmarshal(contents, fileConfig); err != nil {
+		return nil, err
+	}
+
+	return fileConfig, nil
+}
+
+// parsePropertiesResourceConfig parses a flat "key=value" per line file (# starts a comment) into
+// the same shape YAML produces, so both formats feed the same conversion to ResourceConfig
+func parsePropertiesResourceConfig(contents []byte) (*fileResourceConfig, error) {
+	fileConfig := &fileResourceConfig{}
+
+	for _, line := range strings.Split(string(contents), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("malformed properties line: %q", line)
+		}
+
+		key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+
+		switch key {
+		case "name":
+			fileConfig.Name = value
+		case "methods":
+			fileConfig.Methods = strings.Split(value, ",")
+		case "policy.auth":
+			fileConfig.Policy.Auth = value == "true"
+		case "policy.rateLimitPerSecond":
+			rateLimit, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, err
+			}
+			fileConfig.Policy.RateLimit = rateLimit
+		case "policy.timeout":
+			fileConfig.Policy.TimeoutString = value
+		default:
+			return nil, fmt.Errorf("unknown properties key: %q", key)
+		}
+	}
+
+	return fileConfig, nil
+}
+
+func (fc *fileResourceConfig) toResourceConfig() (ResourceConfig, error) {
+	resourceMethods := make([]resourceMethod, 0, len(fc.Methods))
+
+	for _, methodName := range fc.Methods {
+		methodName = strings.TrimSpace(methodName)
+		if methodName == "" {
+			continue
+		}
+
+		method, err := parseResourceMethod(methodName)
+		if err != nil {
+			return ResourceConfig{}, err
+		}
+
+		resourceMethods = append(resourceMethods, method)
+	}
+
+	policy := RoutePolicy{
+		RequireAuth:        fc.Policy.Auth,
+		RateLimitPerSecond: fc.Policy.RateLimit,
+	}
+
+	if fc.Policy.TimeoutString != "" {
+		timeout, err := time.ParseDuration(fc.Policy.TimeoutString)
+		if err != nil {
+			return ResourceConfig{}, err
+		}
+
+		policy.Timeout = timeout
+	}
+
+	return ResourceConfig{
+		Name:            fc.Name,
+		ResourceMethods: resourceMethods,
+		Policy:          policy,
+	}, nil
+}
+
+// Watch subscribes onChange to be called, with the freshly reloaded configuration, whenever a
+// file in the provider's directory is created, removed or written to. The returned stop function
+// closes the underlying fsnotify watcher
+func (p *FileConfigProvider) Watch(onChange func([]ResourceConfig)) (func(), error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := watcher.Add(p.dir); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case _, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				configs, err := p.Load()
+				if err != nil {
+					continue
+				}
+
+				onChange(configs)
+
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		watcher.Close()
+	}, nil
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"fmt"
+	"time"
+)
+
+// RoutePolicy is the per-resource policy a ResourceConfigProvider can attach to a mount: whether
+// its routes require authentication, how many requests per second they accept, and how long a
+// request may run before it's aborted
+type RoutePolicy struct {
+	RequireAuth        bool
+	RateLimitPerSecond float64
+	Timeout            time.Duration
+}
+
+// ResourceConfig describes a single resource the registry should mount: its name (which also
+// determines the path it's mounted under), the CRUD-ish methods it exposes, and the policy
+// enforced on its routes
+type ResourceConfig struct {
+	Name            string
+	ResourceMethods []resourceMethod
+	Policy          RoutePolicy
+}
+
+// ResourceConfigProvider supplies the set of resources the processor should mount. Watch lets a
+// provider that supports live configuration changes (filesystem, Consul) push updates; providers
+// for which the configuration is fixed for the process lifetime (env vars) can implement it as a
+// no-op that never calls onChange
+type ResourceConfigProvider interface {
+	// Load returns the current set of resource configurations
+	Load() ([]ResourceConfig, error)
+
+	// Watch subscribes onChange to be called, with the full new configuration, whenever the
+	// underlying source changes. It returns a function that stops the subscription
+	Watch(onChange func([]ResourceConfig)) (stop func(), err error)
+}
+
+// resourceMethodNames maps the name a ResourceConfigProvider uses for a method in configuration
+// (YAML/properties "methods" lists, env var values, Consul KV values) to its resourceMethod
+var resourceMethodNames = map[string]resourceMethod{
+	"getList":   resourceMethodGetList,
+	"getDetail": resourceMethodGetDetail,
+	"create":    resourceMethodCreate,
+	"update":    resourceMethodUpdate,
+	"delete":    resourceMethodDelete,
+	"stream":    resourceMethodStream,
+}
+
+// parseResourceMethod resolves a configured method name (see resourceMethodNames) to a
+// resourceMethod, or returns an error if it isn't recognized
+func parseResourceMethod(name string) (resourceMethod, error) {
+	method, ok := resourceMethodNames[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown resource method %q", name)
+	}
+
+	return method, nil
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CORSConfiguration controls the cross-origin filter attached to a resource's router
+type CORSConfiguration struct {
+	Enabled          bool
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+	MaxAgeSeconds    int
+}
+
+// CompressionConfiguration controls the response-compressing filter attached to a resource's router
+type CompressionConfiguration struct {
+	Enabled bool
+}
+
+// MiddlewareConfiguration is the processor-level configuration driving the cross-cutting filters
+// every resource router gets at mount time
+type MiddlewareConfiguration struct {
+	CORS        CORSConfiguration
+	Compression CompressionConfiguration
+}
+
+// DefaultMiddlewareConfiguration returns the middleware configuration resources get when none is
+// explicitly supplied: permissive CORS and gzip/deflate compression, both enabled
+func DefaultMiddlewareConfiguration() *MiddlewareConfiguration {
+	return &MiddlewareConfiguration{
+		CORS: CORSConfiguration{
+			Enabled:        true,
+			AllowedOrigins: []string{"*"},
+			AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions},
+			AllowedHeaders: []string{"Content-Type", "Accept"},
+			MaxAgeSeconds:  600,
+		},
+		Compression: CompressionConfiguration{
+			Enabled: true,
+		},
+	}
+}
+
+// attachMiddleware wires the configured filters onto the resource's router. It is called once,
+// from Initialize. The router runs its middleware around route matching, so the filters also see
+// requests (like preflight OPTIONS) that don't match a handler
+func (ar *abstractResource) attachMiddleware() {
+	if ar.middleware == nil {
+		ar.middleware = DefaultMiddlewareConfiguration()
+	}
+
+	if ar.middleware.CORS.Enabled {
+		ar.router.Use(ar.corsFilter)
+	}
+
+	if ar.middleware.Compression.Enabled {
+		ar.router.Use(compressionFilter)
+	}
+}
+
+// corsFilter implements preflight handling and response header injection per ar.middleware.CORS
+func (ar *abstractResource) corsFilter(next http.Handler) http.Handler {
+	config := ar.middleware.CORS
+
+	return http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		origin := request.Header.Get("Origin")
+
+		if origin == "" {
+			next.ServeHTTP(responseWriter, request)
+			return
+		}
+
+		if !originAllowed(origin, config.AllowedOrigins) {
+			next.ServeHTTP(responseWriter, request)
+			return
+		}
+
+		header := responseWriter.Header()
+
+		if allowAllOrigins(config.AllowedOrigins) && !config.AllowCredentials {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+			header.Set("Vary", "Origin")
+		}
+
+		if config.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		// preflight request: answer it directly, don't invoke the handler
+		if request.Method == http.MethodOptions && request.Header.Get("Access-Control-Request-Method") != "" {
+			header.Set("Access-Control-Allow-Methods", strings.Join(config.AllowedMethods, ", "))
+			header.Set("Access-Control-Allow-Headers", strings.Join(config.AllowedHeaders, ", "))
+			header.Set("Access-Control-Max-Age", strconv.Itoa(config.MaxAgeSeconds))
+			responseWriter.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(responseWriter, request)
+	})
+}
+
+func allowAllOrigins(allowedOrigins []string) bool {
+	for _, allowedOrigin := range allowedOrigins {
+		if allowedOrigin == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func originAllowed(origin string, allowedOrigins []string) bool {
+	for _, allowedOrigin := range allowedOrigins {
+		if allowedOrigin == "*" || allowedOrigin == origin {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ResponseEncoder renders a resource envelope onto the wire in some media type. New types
+// (e.g. MessagePack, YAML) register themselves via RegisterResponseEncoder without the resource
+// handlers ever knowing which one ends up being used
+type ResponseEncoder interface {
+	Encode(writer io.Writer, v interface{}) error
+}
+
+type jsonResponseEncoder struct{}
+
+func (e *jsonResponseEncoder) Encode(writer io.Writer, v interface{}) error {
+	return json.NewEncoder(writer).Encode(v)
+}
+
+// defaultContentType is the media type served when the client didn't ask for anything (no
+// Accept header) or asked for something we don't recognize
+const defaultContentType = "application/vnd.api+json"
+
+// responseEncoders maps an Accept media type to the encoder that serves it. "application/json"
+// and "application/vnd.api+json" are always available; RegisterResponseEncoder adds more
+var responseEncoders = map[string]ResponseEncoder{
+	"application/json": &jsonResponseEncoder{},
+	defaultContentType: &jsonResponseEncoder{},
+}
+
+// RegisterResponseEncoder makes encoder available for mediaType (e.g. "application/x-msgpack").
+// Resources don't need to change to take advantage of a newly registered encoder - content
+// negotiation picks it automatically based on the client's Accept header
+func RegisterResponseEncoder(mediaType string, encoder ResponseEncoder) {
+	responseEncoders[mediaType] = encoder
+}
+
+// negotiateEncoder picks the Content-Type and ResponseEncoder for request's Accept header,
+// honoring quality values and falling back to the default (JSON-API) encoder when nothing
+// recognized was requested (including no Accept header, or "*/*")
+func negotiateEncoder(request *http.Request) (string, ResponseEncoder) {
+	accept := request.Header.Get("Accept")
+
+	for _, mediaType := range rankAcceptedMediaTypes(accept) {
+		if mediaType == "*/*" {
+			break
+		}
+
+		if encoder, found := responseEncoders[mediaType]; found {
+			return mediaType, encoder
+		}
+	}
+
+	return defaultContentType, responseEncoders[defaultContentType]
+}
+
+// rankAcceptedMediaTypes splits an Accept header into its media types, ordered by descending
+// "q" value (ties keep their original relative order)
+func rankAcceptedMediaTypes(accept string) []string {
+	type weightedMediaType struct {
+		mediaType string
+		quality   float64
+	}
+
+	var weighted []weightedMediaType
+
+	for _, part := range strings.Split(accept, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		mediaType := part
+		quality := 1.0
+
+		if semicolon := strings.Index(part, ";"); semicolon != -1 {
+			mediaType = strings.TrimSpace(part[:semicolon])
+
+			for _, param := range strings.Split(part[semicolon+1:], ";") {
+				param = strings.TrimSpace(param)
+				if strings.HasPrefix(param, "q=") {
+					if parsedQuality, err := parseQuality(param[2:]); err == nil {
+						quality = parsedQuality
+					}
+				}
+			}
+		}
+
+		weighted = append(weighted, weightedMediaType{mediaType, quality})
+	}
+
+	// stable sort by descending quality
+	for i := 1; i < len(weighted); i++ {
+		for j := i; j > 0 && weighted[j].quality > weighted[j-1].quality; j-- {
+			weighted[j], weighted[j-1] = weighted[j-1], weighted[j]
+		}
+	}
+
+	mediaTypes := make([]string, len(weighted))
+	for i, w := range weighted {
+		mediaTypes[i] = w.mediaType
+	}
+
+	return mediaTypes
+}
+
+func parseQuality(s string) (float64, error) {
+	var quality float64
+	_, err := fmt.Sscanf(s, "%f", &quality)
+	return quality, err
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// policyFilter builds the middleware enforcing a RoutePolicy: authentication, a request rate
+// limit, and a per-request timeout, each only applied if the policy asks for it. The timeout
+// wrapper is skipped for stream requests (GET /{id}/events): http.TimeoutHandler's response
+// writer implements neither http.Flusher nor http.Hijacker, so wrapping it would turn SSE and
+// websocket upgrades on a stream resource into a hard 500/failed-hijack the moment Policy.Timeout
+// is set, regardless of how long the caller actually meant the stream to live
+func policyFilter(policy RoutePolicy) func(http.Handler) http.Handler {
+	limiter := newRateLimiter(policy.RateLimitPerSecond)
+
+	return func(next http.Handler) http.Handler {
+		timeoutHandler := next
+		if policy.Timeout > 0 {
+			timeoutHandler = http.TimeoutHandler(next, policy.Timeout, "request timed out")
+		}
+
+		return http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+			if policy.RequireAuth && request.Header.Get("Authorization") == "" {
+				http.Error(responseWriter, "authorization required", http.StatusUnauthorized)
+				return
+			}
+
+			if limiter != nil && !limiter.allow() {
+				http.Error(responseWriter, "rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+
+			if isStreamRequest(request) {
+				next.ServeHTTP(responseWriter, request)
+				return
+			}
+
+			timeoutHandler.ServeHTTP(responseWriter, request)
+		})
+	}
+}
+
+// isStreamRequest reports whether request targets a resource's GET /{id}/events stream route -
+// the one route shape policy middleware that assumes a bounded, buffering response writer (like
+// http.TimeoutHandler) must not wrap
+func isStreamRequest(request *http.Request) bool {
+	return strings.HasSuffix(request.URL.Path, "/events")
+}
+
+// rateLimiter is a simple token bucket refilled at a fixed rate, good enough for bounding a
+// resource's request rate without pulling in a dedicated rate-limiting dependency
+type rateLimiter struct {
+	mu         sync.Mutex
+	tokens     float64
+	maxTokens  float64
+	perSecond  float64
+	lastRefill time.Time
+}
+
+// newRateLimiter returns nil (no limiting) when perSecond is non-positive
+func newRateLimiter(perSecond float64) *rateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+
+	return &rateLimiter{
+		tokens:     perSecond,
+		maxTokens:  perSecond,
+		perSecond:  perSecond,
+		lastRefill: time.Now(),
+	}
+}
+
+func (l *rateLimiter) allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(l.lastRefill).Seconds()
+	l.lastRefill = now
+
+	l.tokens += elapsed * l.perSecond
+	if l.tokens > l.maxTokens {
+		l.tokens = l.maxTokens
+	}
+
+	if l.tokens < 1 {
+		return false
+	}
+
+	l.tokens--
+
+	return true
+}
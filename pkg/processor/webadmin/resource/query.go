@@ -0,0 +1,281 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultPageLimit is applied when the request doesn't specify page[limit]
+const defaultPageLimit = 100
+
+// SortField is one comma-separated element of a JSON-API "sort" query param (e.g. the "-a2" in
+// "?sort=-a2,a1")
+type SortField struct {
+	Field      string
+	Descending bool
+}
+
+// PageRequest is the parsed "page[offset]"/"page[limit]" pair
+type PageRequest struct {
+	Offset int
+	Limit  int
+}
+
+// ResourceQuery is the parsed form of the JSON-API query parameters this framework understands:
+// ?filter[attr]=val, ?fields[type]=a1,a2, ?sort=-a2,a1 and ?page[offset]=N&page[limit]=M
+type ResourceQuery struct {
+	Filter map[string]string
+	Fields map[string][]string
+	Sort   []SortField
+	Page   PageRequest
+}
+
+// PageInfo describes where, within the full (filtered) collection, a page of items sits. It's
+// returned by getList alongside the page itself so the envelope can render links and meta.total
+type PageInfo struct {
+	Total  int
+	Offset int
+	Limit  int
+}
+
+// Item is a single JSON-API resource object, as returned in bulk by a resource's getList
+type Item struct {
+	ID         string
+	Attributes attributes
+}
+
+// getListResource is implemented by resources that expose a queryable, multi-row collection at
+// GET / (as opposed to getSingleResource, for resources that are inherently a singleton)
+type getListResource interface {
+	getList(request *http.Request, query *ResourceQuery) ([]Item, PageInfo, error)
+}
+
+// parseResourceQuery extracts a ResourceQuery from request's query string
+func parseResourceQuery(request *http.Request) *ResourceQuery {
+	query := &ResourceQuery{
+		Filter: map[string]string{},
+		Fields: map[string][]string{},
+		Page:   PageRequest{Limit: defaultPageLimit},
+	}
+
+	for key, values := range request.URL.Query() {
+		if len(values) == 0 {
+			continue
+		}
+
+		value := values[0]
+
+		switch {
+		case strings.HasPrefix(key, "filter[") && strings.HasSuffix(key, "]"):
+			attribute := key[len("filter[") : len(key)-1]
+			query.Filter[attribute] = value
+		case strings.HasPrefix(key, "fields[") && strings.HasSuffix(key, "]"):
+			resourceType := key[len("fields[") : len(key)-1]
+			query.Fields[resourceType] = strings.Split(value, ",")
+		case key == "sort":
+			query.Sort = parseSortFields(value)
+		case key == "page[offset]":
+			if offset, err := strconv.Atoi(value); err == nil && offset >= 0 {
+				query.Page.Offset = offset
+			}
+		case key == "page[limit]":
+			if limit, err := strconv.Atoi(value); err == nil && limit >= 0 {
+				query.Page.Limit = limit
+			}
+		}
+	}
+
+	return query
+}
+
+func parseSortFields(value string) []SortField {
+	var sortFields []SortField
+
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		if strings.HasPrefix(field, "-") {
+			sortFields = append(sortFields, SortField{Field: field[1:], Descending: true})
+		} else {
+			sortFields = append(sortFields, SortField{Field: field})
+		}
+	}
+
+	return sortFields
+}
+
+// ApplyQuery filters, sorts, paginates and sparsifies items entirely in memory, per query. It
+// lets a resource's getList hand over its full, unfiltered collection and get JSON-API querying
+// for free, rather than every resource having to implement filtering/sorting/paging itself
+func ApplyQuery(resourceType string, items []Item, query *ResourceQuery) ([]Item, PageInfo) {
+	filtered := filterItems(items, query.Filter)
+
+	sortItems(filtered, query.Sort)
+
+	pageInfo := PageInfo{
+		Total:  len(filtered),
+		Offset: query.Page.Offset,
+		Limit:  query.Page.Limit,
+	}
+
+	paged := paginateItems(filtered, query.Page)
+	sparse := sparsifyItems(resourceType, paged, query.Fields)
+
+	return sparse, pageInfo
+}
+
+func filterItems(items []Item, filter map[string]string) []Item {
+	if len(filter) == 0 {
+		return items
+	}
+
+	filtered := make([]Item, 0, len(items))
+
+	for _, item := range items {
+		if itemMatchesFilter(item, filter) {
+			filtered = append(filtered, item)
+		}
+	}
+
+	return filtered
+}
+
+func itemMatchesFilter(item Item, filter map[string]string) bool {
+	for attribute, value := range filter {
+		if fmt.Sprintf("%v", item.Attributes[attribute]) != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sortItems(items []Item, sortFields []SortField) {
+	if len(sortFields) == 0 {
+		return
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		for _, sortField := range sortFields {
+			less, equal := compareAttribute(items[i].Attributes[sortField.Field], items[j].Attributes[sortField.Field])
+			if equal {
+				continue
+			}
+
+			if sortField.Descending {
+				return !less
+			}
+
+			return less
+		}
+
+		return false
+	})
+}
+
+// compareAttribute reports whether a sorts before b, and whether the two are equal. Numeric
+// values are compared as numbers rather than as their string representations, so a "score" of 10
+// sorts after 9 instead of before it lexicographically; anything else falls back to string
+// comparison
+func compareAttribute(a, b interface{}) (less bool, equal bool) {
+	if aNum, bNum, ok := asFloat64Pair(a, b); ok {
+		return aNum < bNum, aNum == bNum
+	}
+
+	aStr := fmt.Sprintf("%v", a)
+	bStr := fmt.Sprintf("%v", b)
+
+	return aStr < bStr, aStr == bStr
+}
+
+// asFloat64Pair returns a and b as float64, and true, if both are one of the numeric types a
+// JSON-decoded or hand-built attribute map is likely to hold
+func asFloat64Pair(a, b interface{}) (float64, float64, bool) {
+	aNum, ok := asFloat64(a)
+	if !ok {
+		return 0, 0, false
+	}
+
+	bNum, ok := asFloat64(b)
+	if !ok {
+		return 0, 0, false
+	}
+
+	return aNum, bNum, true
+}
+
+func asFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func paginateItems(items []Item, page PageRequest) []Item {
+	if page.Offset >= len(items) {
+		return []Item{}
+	}
+
+	end := len(items)
+	if page.Limit > 0 && page.Offset+page.Limit < end {
+		end = page.Offset + page.Limit
+	}
+
+	return items[page.Offset:end]
+}
+
+func sparsifyItems(resourceType string, items []Item, fields map[string][]string) []Item {
+	allowed, found := fields[resourceType]
+	if !found {
+		return items
+	}
+
+	sparse := make([]Item, len(items))
+	for i, item := range items {
+		sparse[i] = Item{ID: item.ID, Attributes: pickAttributes(item.Attributes, allowed)}
+	}
+
+	return sparse
+}
+
+func pickAttributes(fullAttributes attributes, allowed []string) attributes {
+	picked := attributes{}
+
+	for _, field := range allowed {
+		if value, ok := fullAttributes[field]; ok {
+			picked[field] = value
+		}
+	}
+
+	return picked
+}
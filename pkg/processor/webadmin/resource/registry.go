@@ -0,0 +1,144 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"net/http"
+	"reflect"
+	"sync"
+
+	"github.com/nuclio/nuclio/cmd/processor/app"
+
+	"github.com/nuclio/nuclio-sdk"
+)
+
+// ResourceFactory builds the concrete resource for a ResourceConfig, embedding abstractResource
+// the way every hand-wired resource in this package does (e.g. &fooResource{abstractResource: ar})
+type ResourceFactory func(config ResourceConfig, abstractResource *abstractResource) interface{}
+
+// mountedResource is what the registry remembers about a resource it has mounted, so
+// ReloadResources can tell whether a later config for the same name actually changed anything
+type mountedResource struct {
+	config   ResourceConfig
+	resource *abstractResource
+}
+
+// Registry mounts resources onto Router() per a ResourceConfigProvider's configuration, and
+// reconciles the mounted set against updated configuration - via ReloadResources - without
+// restarting the HTTP server Router() is served from
+type Registry struct {
+	logger    nuclio.Logger
+	processor *app.Processor
+	provider  ResourceConfigProvider
+	factory   ResourceFactory
+	router    *registryRouter
+
+	mu      sync.Mutex
+	mounted map[string]*mountedResource
+}
+
+// NewRegistry creates a Registry that builds resources with factory and configures them from provider
+func NewRegistry(logger nuclio.Logger,
+	processor *app.Processor,
+	provider ResourceConfigProvider,
+	factory ResourceFactory) *Registry {
+
+	return &Registry{
+		logger:    logger.GetChild("registry"),
+		processor: processor,
+		provider:  provider,
+		factory:   factory,
+		router:    newRegistryRouter(),
+		mounted:   map[string]*mountedResource{},
+	}
+}
+
+// Router returns the http.Handler resources are mounted on. It never changes identity across a
+// ReloadResources call, so it's safe to hand to an http.Server once at startup
+func (r *Registry) Router() http.Handler {
+	return r.router
+}
+
+// Start loads the provider's initial configuration and, for providers that support it, subscribes
+// to further changes - each one reconciled via ReloadResources
+func (r *Registry) Start() error {
+	configs, err := r.provider.Load()
+	if err != nil {
+		return err
+	}
+
+	if err := r.ReloadResources(configs); err != nil {
+		return err
+	}
+
+	_, err = r.provider.Watch(func(configs []ResourceConfig) {
+		if err := r.ReloadResources(configs); err != nil {
+			r.logger.WarnWith("Failed to reload resources", "error", err)
+		}
+	})
+
+	return err
+}
+
+// ReloadResources diffs configs against what's currently mounted: resources that are new or whose
+// configuration changed are (re)built and mounted, and ones no longer present are unmounted - all
+// without disturbing resources whose configuration didn't change
+func (r *Registry) ReloadResources(configs []ResourceConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	seen := map[string]bool{}
+
+	for _, config := range configs {
+		seen[config.Name] = true
+
+		if existing, ok := r.mounted[config.Name]; ok && reflect.DeepEqual(existing.config, config) {
+			continue
+		}
+
+		mounted, err := r.mountResource(config)
+		if err != nil {
+			return err
+		}
+
+		r.mounted[config.Name] = mounted
+	}
+
+	for name := range r.mounted {
+		if !seen[name] {
+			r.router.unmount("/" + name)
+			delete(r.mounted, name)
+		}
+	}
+
+	return nil
+}
+
+func (r *Registry) mountResource(config ResourceConfig) (*mountedResource, error) {
+	abstractRes := newAbstractInterface(config.Name, config.ResourceMethods)
+	abstractRes.resource = r.factory(config, abstractRes)
+
+	if err := abstractRes.Initialize(r.logger, r.processor); err != nil {
+		return nil, err
+	}
+
+	abstractRes.router.Use(policyFilter(config.Policy))
+
+	r.router.mount("/"+config.Name, abstractRes.router)
+
+	return &mountedResource{config: config, resource: abstractRes}, nil
+}
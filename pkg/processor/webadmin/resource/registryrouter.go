@@ -0,0 +1,84 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// registryRouter is a mutex-guarded prefix router a Registry mounts and unmounts resource
+// subtrees on. Unlike a chi.Router, subtrees can be unmounted at any time, which is what lets
+// ReloadResources add and remove resources while the HTTP server serving it keeps running
+type registryRouter struct {
+	mu     sync.RWMutex
+	mounts map[string]http.Handler
+}
+
+func newRegistryRouter() *registryRouter {
+	return &registryRouter{
+		mounts: map[string]http.Handler{},
+	}
+}
+
+func (r *registryRouter) mount(prefix string, handler http.Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.mounts[prefix] = handler
+}
+
+func (r *registryRouter) unmount(prefix string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.mounts, prefix)
+}
+
+func (r *registryRouter) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
+	r.mu.RLock()
+	handler, subPath, ok := r.match(request.URL.Path)
+	r.mu.RUnlock()
+
+	if !ok {
+		http.NotFound(responseWriter, request)
+		return
+	}
+
+	subRequest := request.Clone(request.Context())
+	subRequest.URL.Path = subPath
+
+	handler.ServeHTTP(responseWriter, subRequest)
+}
+
+func (r *registryRouter) match(path string) (http.Handler, string, bool) {
+	for prefix, handler := range r.mounts {
+		if path != prefix && !strings.HasPrefix(path, prefix+"/") {
+			continue
+		}
+
+		subPath := strings.TrimPrefix(path, prefix)
+		if subPath == "" {
+			subPath = "/"
+		}
+
+		return handler, subPath, true
+	}
+
+	return nil, "", false
+}
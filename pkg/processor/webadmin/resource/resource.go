@@ -0,0 +1,326 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/nuclio/nuclio/cmd/processor/app"
+
+	"github.com/nuclio/nuclio-sdk"
+)
+
+// attributes holds the JSON-API "attributes" member of a resource object
+type attributes map[string]interface{}
+
+// customRouteFunc is the signature custom (non-CRUD) routes must implement. It returns the
+// resource "type", a map of resource ID to its attributes, whether the response is a single
+// resource object (true) or a collection (false), and an error
+type customRouteFunc func(request *http.Request) (string, map[string]attributes, bool, error)
+
+// customRoute pairs an HTTP method with the handler that serves it
+type customRoute struct {
+	method  string
+	handler customRouteFunc
+}
+
+// resourceMethod enumerates the CRUD-ish operations a resource can opt into
+type resourceMethod int
+
+const (
+	resourceMethodGetList resourceMethod = iota
+	resourceMethodGetDetail
+	resourceMethodCreate
+	resourceMethodUpdate
+	resourceMethodDelete
+	resourceMethodStream
+)
+
+// getSingleResource is implemented by resources that opt into resourceMethodGetList and return
+// a single, singleton resource object (e.g. a resource with no concept of multiple instances)
+type getSingleResource interface {
+	getSingle(request *http.Request) (string, attributes)
+}
+
+// getDetailResource is implemented by resources that opt into resourceMethodGetDetail
+type getDetailResource interface {
+	getByID(request *http.Request, id string) attributes
+}
+
+// customRoutesResource is implemented by resources that expose routes beyond the standard CRUD set
+type customRoutesResource interface {
+	getCustomRoutes() map[string]customRoute
+}
+
+// abstractResource is the base all concrete resources embed. It owns the trie-backed sub-router
+// mounted for the resource and renders responses in the JSON-API envelope
+type abstractResource struct {
+	logger          nuclio.Logger
+	name            string
+	resourceMethods []resourceMethod
+	router          *routeTrie
+	processor       *app.Processor
+	resource        interface{}
+	middleware      *MiddlewareConfiguration
+}
+
+// newAbstractInterface creates an abstractResource for "name", supporting the given methods.
+// Middleware (CORS, compression, content negotiation) is attached with sane defaults; use
+// newAbstractInterfaceWithMiddleware to customize it
+func newAbstractInterface(name string, resourceMethods []resourceMethod) *abstractResource {
+	return newAbstractInterfaceWithMiddleware(name, resourceMethods, DefaultMiddlewareConfiguration())
+}
+
+// newAbstractInterfaceWithMiddleware is like newAbstractInterface but lets the caller supply a
+// processor-level middleware configuration instead of the defaults
+func newAbstractInterfaceWithMiddleware(name string,
+	resourceMethods []resourceMethod,
+	middleware *MiddlewareConfiguration) *abstractResource {
+
+	return &abstractResource{
+		name:            name,
+		resourceMethods: resourceMethods,
+		router:          newRouteTrie(),
+		middleware:      middleware,
+	}
+}
+
+// Initialize wires the resource to its logger and processor and mounts its routes and middleware.
+// It is called once, by the registry, as the resource is mounted into the root router
+func (ar *abstractResource) Initialize(logger nuclio.Logger, processor *app.Processor) error {
+	ar.logger = logger.GetChild(ar.name)
+	ar.processor = processor
+
+	// middleware wraps the whole sub-router (routeTrie runs it around route matching), so it sees
+	// every request, including ones that don't match any route (e.g. preflight)
+	ar.attachMiddleware()
+
+	ar.registerRoutes()
+
+	return nil
+}
+
+func (ar *abstractResource) registerRoutes() {
+	for _, resourceMethod := range ar.resourceMethods {
+		switch resourceMethod {
+		case resourceMethodGetList:
+			if _, ok := ar.resource.(getListResource); ok {
+				ar.router.Get("/", ar.handleGetCollection)
+			} else {
+				ar.router.Get("/", ar.handleGetSingleton)
+			}
+		case resourceMethodGetDetail:
+			ar.router.Get("/{id}", ar.handleGetDetail)
+		case resourceMethodStream:
+			ar.router.Get("/{id}/events", ar.handleStream)
+		}
+	}
+
+	if customRoutesResource, ok := ar.resource.(customRoutesResource); ok {
+		for path, route := range customRoutesResource.getCustomRoutes() {
+			ar.router.Method(route.method, path, ar.wrapCustomRoute(route.handler))
+		}
+	}
+}
+
+func (ar *abstractResource) handleGetSingleton(responseWriter http.ResponseWriter, request *http.Request) {
+	getSingleResource, ok := ar.resource.(getSingleResource)
+	if !ok {
+		http.NotFound(responseWriter, request)
+		return
+	}
+
+	id, resourceAttributes := getSingleResource.getSingle(request)
+
+	ar.writeSingle(responseWriter, request, id, resourceAttributes)
+}
+
+func (ar *abstractResource) handleGetCollection(responseWriter http.ResponseWriter, request *http.Request) {
+	getListResource, ok := ar.resource.(getListResource)
+	if !ok {
+		http.NotFound(responseWriter, request)
+		return
+	}
+
+	query := parseResourceQuery(request)
+
+	items, pageInfo, err := getListResource.getList(request, query)
+	if err != nil {
+		http.Error(responseWriter, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ar.writeCollection(responseWriter, request, items, pageInfo)
+}
+
+func (ar *abstractResource) handleGetDetail(responseWriter http.ResponseWriter, request *http.Request) {
+	getDetailResource, ok := ar.resource.(getDetailResource)
+	if !ok {
+		http.NotFound(responseWriter, request)
+		return
+	}
+
+	id := ParamsFromRequest(request).Get("id")
+	resourceAttributes := getDetailResource.getByID(request, id)
+
+	if resourceAttributes == nil {
+		http.NotFound(responseWriter, request)
+		return
+	}
+
+	ar.writeSingle(responseWriter, request, id, resourceAttributes)
+}
+
+func (ar *abstractResource) wrapCustomRoute(handler customRouteFunc) http.HandlerFunc {
+	return func(responseWriter http.ResponseWriter, request *http.Request) {
+		resourceType, idToAttributes, single, err := handler(request)
+		if err != nil {
+			http.Error(responseWriter, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if len(idToAttributes) == 0 {
+			ar.encodeResponse(responseWriter, request, struct{}{})
+			return
+		}
+
+		if single {
+			for id, resourceAttributes := range idToAttributes {
+				ar.writeSingleAs(responseWriter, request, resourceType, id, resourceAttributes)
+				return
+			}
+		}
+
+		ar.writeMultipleAs(responseWriter, request, resourceType, idToAttributes)
+	}
+}
+
+func (ar *abstractResource) writeSingle(responseWriter http.ResponseWriter,
+	request *http.Request,
+	id string,
+	resourceAttributes attributes) {
+	ar.writeSingleAs(responseWriter, request, ar.name, id, resourceAttributes)
+}
+
+func (ar *abstractResource) writeSingleAs(responseWriter http.ResponseWriter,
+	request *http.Request,
+	resourceType string,
+	id string,
+	resourceAttributes attributes) {
+
+	ar.encodeResponse(responseWriter, request, map[string]interface{}{
+		"data": ar.resourceObject(resourceType, id, resourceAttributes),
+	})
+}
+
+func (ar *abstractResource) writeMultipleAs(responseWriter http.ResponseWriter,
+	request *http.Request,
+	resourceType string,
+	idToAttributes map[string]attributes) {
+
+	ids := make([]string, 0, len(idToAttributes))
+	for id := range idToAttributes {
+		ids = append(ids, id)
+	}
+
+	// a map has no order of its own - sort the IDs so the response is deterministic
+	sort.Strings(ids)
+
+	data := make([]interface{}, 0, len(idToAttributes))
+	for _, id := range ids {
+		data = append(data, ar.resourceObject(resourceType, id, idToAttributes[id]))
+	}
+
+	ar.encodeResponse(responseWriter, request, map[string]interface{}{
+		"data": data,
+	})
+}
+
+func (ar *abstractResource) writeCollection(responseWriter http.ResponseWriter,
+	request *http.Request,
+	items []Item,
+	pageInfo PageInfo) {
+
+	data := make([]interface{}, len(items))
+	for i, item := range items {
+		data[i] = ar.resourceObject(ar.name, item.ID, item.Attributes)
+	}
+
+	ar.encodeResponse(responseWriter, request, map[string]interface{}{
+		"data":  data,
+		"links": pageLinks(request, pageInfo),
+		"meta": map[string]interface{}{
+			"total": pageInfo.Total,
+		},
+	})
+}
+
+// pageLinks renders links.self/next/prev per the JSON-API pagination spec, by rewriting the
+// request's own page[offset]/page[limit] query params
+func pageLinks(request *http.Request, pageInfo PageInfo) map[string]interface{} {
+	links := map[string]interface{}{
+		"self": pageURL(request, pageInfo.Offset, pageInfo.Limit),
+	}
+
+	if pageInfo.Limit > 0 && pageInfo.Offset+pageInfo.Limit < pageInfo.Total {
+		links["next"] = pageURL(request, pageInfo.Offset+pageInfo.Limit, pageInfo.Limit)
+	}
+
+	if pageInfo.Offset > 0 {
+		previousOffset := pageInfo.Offset - pageInfo.Limit
+		if previousOffset < 0 {
+			previousOffset = 0
+		}
+
+		links["prev"] = pageURL(request, previousOffset, pageInfo.Limit)
+	}
+
+	return links
+}
+
+func pageURL(request *http.Request, offset int, limit int) string {
+	query := request.URL.Query()
+	query.Set("page[offset]", strconv.Itoa(offset))
+	query.Set("page[limit]", strconv.Itoa(limit))
+
+	url := *request.URL
+	url.RawQuery = query.Encode()
+
+	return url.String()
+}
+
+func (ar *abstractResource) resourceObject(resourceType string, id string, resourceAttributes attributes) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         id,
+		"type":       resourceType,
+		"attributes": resourceAttributes,
+	}
+}
+
+// encodeResponse renders body using the encoder negotiated from the request's Accept header,
+// falling back to the default JSON-API encoder
+func (ar *abstractResource) encodeResponse(responseWriter http.ResponseWriter, request *http.Request, body interface{}) {
+	contentType, encoder := negotiateEncoder(request)
+
+	responseWriter.Header().Set("Content-Type", contentType)
+
+	if err := encoder.Encode(responseWriter, body); err != nil {
+		ar.logger.WarnWith("Failed to encode response", "error", err)
+	}
+}
@@ -17,20 +17,30 @@ limitations under the License.
 package resource
 
 import (
+	"bufio"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/nuclio/nuclio-sdk"
 	"github.com/nuclio/nuclio/pkg/zap"
 
 	"github.com/go-chi/chi"
+	"github.com/gorilla/websocket"
 	"github.com/nuclio/nuclio/cmd/processor/app"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -61,14 +71,16 @@ func (fr *fooResource) getByID(request *http.Request, id string) attributes {
 
 func (fr *fooResource) getCustomRoutes() map[string]customRoute {
 	return map[string]customRoute{
-		"/{id}/single": {http.MethodGet, fr.getCustomSingle},
-		"/{id}/multi":  {http.MethodGet, fr.getCustomMulti},
-		"/post":        {http.MethodPost, fr.postCustom},
+		"/{id}/single":           {http.MethodGet, fr.getCustomSingle},
+		"/{id}/multi":            {http.MethodGet, fr.getCustomMulti},
+		"/post":                  {http.MethodPost, fr.postCustom},
+		"/typed-int/{num:int}":   {http.MethodGet, fr.getTypedInt},
+		"/typed-uuid/{uid:uuid}": {http.MethodGet, fr.getTypedUUID},
 	}
 }
 
 func (fr *fooResource) getCustomSingle(request *http.Request) (string, map[string]attributes, bool, error) {
-	resourceID := chi.URLParam(request, "id")
+	resourceID := ParamsFromRequest(request).Get("id")
 
 	return "getCustomSingle", map[string]attributes{
 		resourceID: {"a": "b", "c": "d"},
@@ -76,7 +88,7 @@ func (fr *fooResource) getCustomSingle(request *http.Request) (string, map[strin
 }
 
 func (fr *fooResource) getCustomMulti(request *http.Request) (string, map[string]attributes, bool, error) {
-	resourceID := chi.URLParam(request, "id")
+	resourceID := ParamsFromRequest(request).Get("id")
 
 	return "getCustomMulti", map[string]attributes{
 		resourceID:        {"a": "b", "c": "d"},
@@ -88,6 +100,72 @@ func (fr *fooResource) postCustom(request *http.Request) (string, map[string]att
 	return "postCustom", nil, true, nil
 }
 
+func (fr *fooResource) getTypedInt(request *http.Request) (string, map[string]attributes, bool, error) {
+	num := ParamsFromRequest(request).Get("num")
+
+	return "getTypedInt", map[string]attributes{
+		num: {"num": num},
+	}, true, nil
+}
+
+func (fr *fooResource) getTypedUUID(request *http.Request) (string, map[string]attributes, bool, error) {
+	uid := ParamsFromRequest(request).Get("uid")
+
+	return "getTypedUUID", map[string]attributes{
+		uid: {"uid": uid},
+	}, true, nil
+}
+
+//
+// Bar resource - multi-row, queryable collection
+//
+
+type barResource struct {
+	*abstractResource
+}
+
+func (br *barResource) getList(request *http.Request, query *ResourceQuery) ([]Item, PageInfo, error) {
+	items := []Item{
+		{ID: "1", Attributes: attributes{"name": "alpha", "score": 3}},
+		{ID: "2", Attributes: attributes{"name": "bravo", "score": 1}},
+		{ID: "3", Attributes: attributes{"name": "charlie", "score": 2}},
+		{ID: "4", Attributes: attributes{"name": "alpha", "score": 4}},
+	}
+
+	page, pageInfo := ApplyQuery("bar", items, query)
+
+	return page, pageInfo, nil
+}
+
+//
+// Baz resource - event stream
+//
+
+type bazResource struct {
+	*abstractResource
+	cleanedUp chan struct{}
+}
+
+// getStream emits an ever-increasing counter, one event at a time, until the client disconnects
+func (bz *bazResource) getStream(request *http.Request) (<-chan Event, error) {
+	events := make(chan Event)
+
+	go func() {
+		defer close(events)
+		defer close(bz.cleanedUp)
+
+		for i := 0; ; i++ {
+			select {
+			case <-request.Context().Done():
+				return
+			case events <- Event{ID: strconv.Itoa(i), Data: i}:
+			}
+		}
+	}()
+
+	return events, nil
+}
+
 //
 // Test suite
 //
@@ -96,6 +174,8 @@ type ResourceTestSuite struct {
 	suite.Suite
 	logger         nuclio.Logger
 	fooResource    *fooResource
+	barResource    *barResource
+	bazResource    *bazResource
 	router         chi.Router
 	processor      *app.Processor
 	testHTTPServer *httptest.Server
@@ -121,6 +201,27 @@ func (suite *ResourceTestSuite) SetupTest() {
 
 	suite.registerResource("foo", suite.fooResource.abstractResource)
 
+	// create the bar resource - a multi-row, queryable collection
+	suite.barResource = &barResource{
+		abstractResource: newAbstractInterface("bar", []resourceMethod{
+			resourceMethodGetList,
+		}),
+	}
+	suite.barResource.resource = suite.barResource
+
+	suite.registerResource("bar", suite.barResource.abstractResource)
+
+	// create the baz resource - an event stream
+	suite.bazResource = &bazResource{
+		abstractResource: newAbstractInterface("baz", []resourceMethod{
+			resourceMethodStream,
+		}),
+		cleanedUp: make(chan struct{}),
+	}
+	suite.bazResource.resource = suite.bazResource
+
+	suite.registerResource("baz", suite.bazResource.abstractResource)
+
 	// set the router as the handler for requests
 	suite.testHTTPServer = httptest.NewServer(suite.router)
 }
@@ -202,6 +303,305 @@ func (suite *ResourceTestSuite) TestFooResourcePostCustom() {
 	suite.sendRequest("POST", "/foo/post", nil, nil, `{}`)
 }
 
+// TestFooResourceGetFallsThroughStaticSibling verifies that a GET to a path which collides with a
+// POST-only custom route ("/post") falls back to the "/{id}" detail route rather than 404ing, the
+// same way the old chi-based router resolved this by trying each registered route in turn
+func (suite *ResourceTestSuite) TestFooResourceGetFallsThroughStaticSibling() {
+	suite.sendRequest("GET", "/foo/post", nil, nil, `{
+		"data": {
+			"id": "post",
+			"type": "foo",
+			"attributes": {
+				"got_id": "post"
+			}
+		}
+	}`)
+}
+
+func (suite *ResourceTestSuite) TestFooResourceTypedIntParam() {
+	suite.sendRequest("GET", "/foo/typed-int/42", nil, nil, `{
+		"data": {
+			"id": "42",
+			"type": "getTypedInt",
+			"attributes": {
+				"num": "42"
+			}
+		}
+	}`)
+}
+
+func (suite *ResourceTestSuite) TestFooResourceTypedIntParamRejectsNonInt() {
+	code := http.StatusNotFound
+	suite.sendRequest("GET", "/foo/typed-int/notanumber", nil, &code, ``)
+}
+
+func (suite *ResourceTestSuite) TestFooResourceTypedUUIDParam() {
+	uid := "550e8400-e29b-41d4-a716-446655440000"
+	suite.sendRequest("GET", "/foo/typed-uuid/"+uid, nil, nil, `{
+		"data": {
+			"id": "`+uid+`",
+			"type": "getTypedUUID",
+			"attributes": {
+				"uid": "`+uid+`"
+			}
+		}
+	}`)
+}
+
+func (suite *ResourceTestSuite) TestFooResourceTypedUUIDParamRejectsNonUUID() {
+	code := http.StatusNotFound
+	suite.sendRequest("GET", "/foo/typed-uuid/not-a-uuid", nil, &code, ``)
+}
+
+func (suite *ResourceTestSuite) TestCORSPreflight() {
+	request, err := http.NewRequest(http.MethodOptions, suite.testHTTPServer.URL+"/foo", nil)
+	suite.Require().NoError(err)
+
+	request.Header.Set("Origin", "http://example.com")
+	request.Header.Set("Access-Control-Request-Method", http.MethodGet)
+
+	response, err := http.DefaultClient.Do(request)
+	suite.Require().NoError(err)
+	defer response.Body.Close()
+
+	suite.Require().Equal(http.StatusNoContent, response.StatusCode)
+	suite.Require().Equal("*", response.Header.Get("Access-Control-Allow-Origin"))
+	suite.Require().Contains(response.Header.Get("Access-Control-Allow-Methods"), http.MethodGet)
+}
+
+func (suite *ResourceTestSuite) TestCORSSimpleRequest() {
+	request, err := http.NewRequest(http.MethodGet, suite.testHTTPServer.URL+"/foo", nil)
+	suite.Require().NoError(err)
+
+	request.Header.Set("Origin", "http://example.com")
+
+	response, err := http.DefaultClient.Do(request)
+	suite.Require().NoError(err)
+	defer response.Body.Close()
+
+	suite.Require().Equal(http.StatusOK, response.StatusCode)
+	suite.Require().Equal("*", response.Header.Get("Access-Control-Allow-Origin"))
+}
+
+func (suite *ResourceTestSuite) TestGzipCompression() {
+	request, err := http.NewRequest(http.MethodGet, suite.testHTTPServer.URL+"/foo", nil)
+	suite.Require().NoError(err)
+
+	request.Header.Set("Accept-Encoding", "gzip")
+
+	response, err := http.DefaultClient.Do(request)
+	suite.Require().NoError(err)
+	defer response.Body.Close()
+
+	suite.Require().Equal("gzip", response.Header.Get("Content-Encoding"))
+
+	gzipReader, err := gzip.NewReader(response.Body)
+	suite.Require().NoError(err)
+	defer gzipReader.Close()
+
+	decoded, err := ioutil.ReadAll(gzipReader)
+	suite.Require().NoError(err)
+
+	decodedBody := map[string]interface{}{}
+	suite.Require().NoError(json.Unmarshal(decoded, &decodedBody))
+	suite.Require().Equal("fooID", decodedBody["data"].(map[string]interface{})["id"])
+}
+
+func (suite *ResourceTestSuite) TestContentNegotiation() {
+	for _, testCase := range []struct {
+		name                string
+		acceptHeader        string
+		expectedContentType string
+	}{
+		{"jsonAPI", "application/vnd.api+json", "application/vnd.api+json"},
+		{"plainJSON", "application/json", "application/json"},
+		{"unspecified", "", "application/vnd.api+json"},
+		{"unsupportedFallsBackToDefault", "application/x-msgpack", "application/vnd.api+json"},
+	} {
+		suite.Run(testCase.name, func() {
+			request, err := http.NewRequest(http.MethodGet, suite.testHTTPServer.URL+"/foo", nil)
+			suite.Require().NoError(err)
+
+			if testCase.acceptHeader != "" {
+				request.Header.Set("Accept", testCase.acceptHeader)
+			}
+
+			response, err := http.DefaultClient.Do(request)
+			suite.Require().NoError(err)
+			defer response.Body.Close()
+
+			suite.Require().Equal(testCase.expectedContentType, response.Header.Get("Content-Type"))
+		})
+	}
+}
+
+func (suite *ResourceTestSuite) TestBarResourceGetListUnfiltered() {
+	_, body := suite.sendRequest("GET", "/bar", nil, nil, "")
+
+	data := body["data"].([]interface{})
+	suite.Require().Len(data, 4)
+	suite.Require().Equal(float64(4), body["meta"].(map[string]interface{})["total"])
+}
+
+func (suite *ResourceTestSuite) TestBarResourceQuery() {
+	for _, testCase := range []struct {
+		name        string
+		query       string
+		expectedIDs []string
+	}{
+		{"filter", "filter[name]=alpha", []string{"1", "4"}},
+		{"sortAscending", "sort=score", []string{"2", "3", "1", "4"}},
+		{"sortDescending", "sort=-score", []string{"4", "1", "3", "2"}},
+		{"pagination", "page[offset]=1&page[limit]=2", []string{"2", "3"}},
+		{"negativeOffsetClampedToZero", "page[offset]=-5", []string{"1", "2", "3", "4"}},
+	} {
+		suite.Run(testCase.name, func() {
+			_, body := suite.sendRequest("GET", "/bar?"+testCase.query, nil, nil, "")
+
+			data := body["data"].([]interface{})
+			suite.Require().Len(data, len(testCase.expectedIDs))
+
+			for i, expectedID := range testCase.expectedIDs {
+				suite.Require().Equal(expectedID, data[i].(map[string]interface{})["id"])
+			}
+		})
+	}
+}
+
+func (suite *ResourceTestSuite) TestBarResourceSparseFieldset() {
+	_, body := suite.sendRequest("GET", "/bar?fields[bar]=name", nil, nil, "")
+
+	data := body["data"].([]interface{})
+	suite.Require().NotEmpty(data)
+
+	for _, item := range data {
+		resourceAttributes := item.(map[string]interface{})["attributes"].(map[string]interface{})
+		suite.Require().Contains(resourceAttributes, "name")
+		suite.Require().NotContains(resourceAttributes, "score")
+	}
+}
+
+func (suite *ResourceTestSuite) TestBarResourcePaginationLinks() {
+	response, body := suite.sendRequest("GET", "/bar?page[offset]=0&page[limit]=2", nil, nil, "")
+	suite.Require().Equal(http.StatusOK, response.StatusCode)
+
+	links := body["links"].(map[string]interface{})
+	suite.Require().Contains(links["self"], "page%5Boffset%5D=0")
+	suite.Require().Contains(links["next"], "page%5Boffset%5D=2")
+	suite.Require().NotContains(links, "prev")
+}
+
+func (suite *ResourceTestSuite) TestStreamSSEOrdering() {
+	request, err := http.NewRequest(http.MethodGet, suite.testHTTPServer.URL+"/baz/someid/events", nil)
+	suite.Require().NoError(err)
+
+	response, err := http.DefaultClient.Do(request)
+	suite.Require().NoError(err)
+	defer response.Body.Close()
+
+	suite.Require().Equal("text/event-stream", response.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(response.Body)
+	lastSeen := -1
+
+	for i := 0; i < 10; i++ {
+		line, err := reader.ReadString('\n')
+		suite.Require().NoError(err)
+
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+
+		value, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "data: ")))
+		suite.Require().NoError(err)
+		suite.Require().Greater(value, lastSeen)
+		lastSeen = value
+	}
+}
+
+func (suite *ResourceTestSuite) TestStreamClientDisconnectCleanup() {
+	request, err := http.NewRequest(http.MethodGet, suite.testHTTPServer.URL+"/baz/someid/events", nil)
+	suite.Require().NoError(err)
+
+	response, err := http.DefaultClient.Do(request)
+	suite.Require().NoError(err)
+
+	// read a single event so we know the stream is up, then disconnect
+	bufio.NewReader(response.Body).ReadString('\n')
+	response.Body.Close()
+
+	select {
+	case <-suite.bazResource.cleanedUp:
+	case <-time.After(5 * time.Second):
+		suite.Require().Fail("server did not clean up after client disconnected")
+	}
+}
+
+func (suite *ResourceTestSuite) TestStreamWebsocketOrdering() {
+	url := "ws://" + strings.TrimPrefix(suite.testHTTPServer.URL, "http://") + "/baz/someid/events"
+
+	connection, _, err := websocket.DefaultDialer.Dial(url, nil)
+	suite.Require().NoError(err)
+	defer connection.Close()
+
+	lastSeen := -1
+
+	for i := 0; i < 10; i++ {
+		var event Event
+
+		suite.Require().NoError(connection.ReadJSON(&event))
+
+		value, err := strconv.Atoi(event.ID)
+		suite.Require().NoError(err)
+		suite.Require().Greater(value, lastSeen)
+		lastSeen = value
+	}
+}
+
+// TestStreamWebsocketUpgradeIgnoresAcceptEncoding guards against compressionFilter swallowing a
+// websocket upgrade: compressedResponseWriter doesn't promote Hijack(), so a client that happens
+// to advertise gzip support alongside the Upgrade request used to get a silent 200 instead of a
+// 101 Switching Protocols
+func (suite *ResourceTestSuite) TestStreamWebsocketUpgradeIgnoresAcceptEncoding() {
+	url := "ws://" + strings.TrimPrefix(suite.testHTTPServer.URL, "http://") + "/baz/someid/events"
+
+	header := http.Header{}
+	header.Set("Accept-Encoding", "gzip")
+
+	connection, _, err := websocket.DefaultDialer.Dial(url, header)
+	suite.Require().NoError(err)
+	defer connection.Close()
+
+	var event Event
+	suite.Require().NoError(connection.ReadJSON(&event))
+}
+
+func (suite *ResourceTestSuite) TestStreamBackPressureDropsOldest() {
+	upstream := make(chan Event)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out := bufferDropOldest(ctx, upstream, 2)
+
+	go func() {
+		for i := 0; i < 10; i++ {
+			upstream <- Event{ID: strconv.Itoa(i)}
+		}
+		close(upstream)
+	}()
+
+	// give the producer a head start so the bounded buffer has to drop, not block
+	time.Sleep(50 * time.Millisecond)
+
+	var received []string
+	for event := range out {
+		received = append(received, event.ID)
+	}
+
+	suite.Require().LessOrEqual(len(received), 3)
+	suite.Require().Equal("9", received[len(received)-1])
+}
+
 func (suite *ResourceTestSuite) registerResource(name string, resource *abstractResource) {
 
 	// initialize the resource
@@ -235,8 +635,10 @@ func (suite *ResourceTestSuite) sendRequest(method string,
 		suite.Require().Equal(*expectedStatusCode, response.StatusCode)
 	}
 
-	// if there's an expected status code, verify it
+	// best-effort decode so callers that only care about the status code (e.g. 404s, which
+	// aren't JSON-API envelopes) can still inspect it without erroring
 	decodedResponseBody := map[string]interface{}{}
+	json.Unmarshal(encodedResponseBody, &decodedResponseBody)
 
 	// if we need to compare bodies
 	if encodedExpectedResponseBody != "" {
@@ -269,4 +671,208 @@ func (suite *ResourceTestSuite) cleanJSONstring(input string) string {
 
 func TestResourceTestSuite(t *testing.T) {
 	suite.Run(t, new(ResourceTestSuite))
-}
\ No newline at end of file
+}
+
+// TestFileConfigProviderReload drives a Registry from a FileConfigProvider watching a temp
+// directory, and asserts the mounted router picks up resources being added and removed from that
+// directory without the HTTP server ever being restarted
+func TestFileConfigProviderReload(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "nuclio-resource-config")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "widget.yaml"), []byte(`
+name: widget
+methods: [getList]
+`), 0644)
+	assert.NoError(err)
+
+	logger, _ := nucliozap.NewNuclioZapTest("test")
+	processor, _ := app.NewProcessor("")
+
+	provider := NewFileConfigProvider(dir)
+
+	registry := NewRegistry(logger, processor, provider, func(config ResourceConfig, abstractResource *abstractResource) interface{} {
+		return &fooResource{abstractResource: abstractResource}
+	})
+
+	err = registry.Start()
+	assert.NoError(err)
+
+	testHTTPServer := httptest.NewServer(registry.Router())
+	defer testHTTPServer.Close()
+
+	// the widget resource, mounted from the initial config file, should answer immediately
+	response, err := http.Get(testHTTPServer.URL + "/widget")
+	assert.NoError(err)
+	response.Body.Close()
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	// a resource that was never configured should not be mounted
+	response, err = http.Get(testHTTPServer.URL + "/gadget")
+	assert.NoError(err)
+	response.Body.Close()
+	assert.Equal(http.StatusNotFound, response.StatusCode)
+
+	// add a second resource file mid-test: the router should start serving it without a restart
+	err = ioutil.WriteFile(filepath.Join(dir, "gadget.yaml"), []byte(`
+name: gadget
+methods: [getList]
+`), 0644)
+	assert.NoError(err)
+
+	assert.True(pollUntil(5*time.Second, func() bool {
+		response, err := http.Get(testHTTPServer.URL + "/gadget")
+		if err != nil {
+			return false
+		}
+		defer response.Body.Close()
+		return response.StatusCode == http.StatusOK
+	}), "router did not pick up the newly added resource")
+
+	// remove the original resource file mid-test: the router should stop serving it, again
+	// without a restart
+	err = os.Remove(filepath.Join(dir, "widget.yaml"))
+	assert.NoError(err)
+
+	assert.True(pollUntil(5*time.Second, func() bool {
+		response, err := http.Get(testHTTPServer.URL + "/widget")
+		if err != nil {
+			return false
+		}
+		defer response.Body.Close()
+		return response.StatusCode == http.StatusNotFound
+	}), "router did not stop serving the removed resource")
+}
+
+// TestSortItemsComparesNumericAttributesNumerically guards against sortItems falling back to
+// stringifying every attribute before comparing: lexicographic comparison would put a "score" of
+// 10 before 2, since "10" < "2" as strings, even though 2 < 10 as numbers
+func TestSortItemsComparesNumericAttributesNumerically(t *testing.T) {
+	assert := assert.New(t)
+
+	items := []Item{
+		{ID: "a", Attributes: attributes{"score": 9}},
+		{ID: "b", Attributes: attributes{"score": 10}},
+		{ID: "c", Attributes: attributes{"score": 2}},
+	}
+
+	sortItems(items, []SortField{{Field: "score"}})
+
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+
+	assert.Equal([]string{"c", "a", "b"}, ids)
+}
+
+// TestEnvConfigProviderLoad asserts EnvConfigProvider groups NUCLIO_RESOURCE_<NAME>_* variables by
+// resource name correctly even when a suffix (RATE_LIMIT) itself contains an underscore - a naive
+// split on the last underscore would otherwise carve "foo_rate" out as a bogus second resource
+func TestEnvConfigProviderLoad(t *testing.T) {
+	assert := assert.New(t)
+
+	provider := &EnvConfigProvider{
+		environ: func() []string {
+			return []string{
+				"NUCLIO_RESOURCE_FOO_METHODS=getList,getDetail",
+				"NUCLIO_RESOURCE_FOO_RATE_LIMIT=10",
+				"NUCLIO_RESOURCE_FOO_AUTH=true",
+				"NUCLIO_RESOURCE_FOO_TIMEOUT=5s",
+				"UNRELATED_VAR=ignored",
+			}
+		},
+	}
+
+	configs, err := provider.Load()
+	assert.NoError(err)
+	assert.Len(configs, 1)
+
+	config := configs[0]
+	assert.Equal("foo", config.Name)
+	assert.ElementsMatch([]resourceMethod{resourceMethodGetList, resourceMethodGetDetail}, config.ResourceMethods)
+	assert.True(config.Policy.RequireAuth)
+	assert.Equal(10.0, config.Policy.RateLimitPerSecond)
+	assert.Equal(5*time.Second, config.Policy.Timeout)
+}
+
+// TestPolicyTimeoutDoesNotBreakStream mounts a stream resource with a configured Policy.Timeout
+// through the registry (as EnvConfigProvider would build it) and asserts its SSE route still
+// upgrades to a 200 streaming response - http.TimeoutHandler's writer doesn't implement
+// http.Flusher, so wrapping the stream route in it would otherwise turn this into a 500
+func TestPolicyTimeoutDoesNotBreakStream(t *testing.T) {
+	assert := assert.New(t)
+
+	provider := &EnvConfigProvider{
+		environ: func() []string {
+			return []string{
+				"NUCLIO_RESOURCE_BAZ_METHODS=stream",
+				"NUCLIO_RESOURCE_BAZ_TIMEOUT=5s",
+			}
+		},
+	}
+
+	logger, _ := nucliozap.NewNuclioZapTest("test")
+	processor, _ := app.NewProcessor("")
+
+	registry := NewRegistry(logger, processor, provider, func(config ResourceConfig, abstractResource *abstractResource) interface{} {
+		return &bazResource{abstractResource: abstractResource, cleanedUp: make(chan struct{})}
+	})
+
+	assert.NoError(registry.Start())
+
+	testHTTPServer := httptest.NewServer(registry.Router())
+	defer testHTTPServer.Close()
+
+	response, err := http.Get(testHTTPServer.URL + "/baz/someid/events")
+	assert.NoError(err)
+	defer response.Body.Close()
+
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal("text/event-stream", response.Header.Get("Content-Type"))
+}
+
+// pollUntil polls condition until it returns true or timeout elapses, returning which happened first
+func pollUntil(timeout time.Duration, condition func() bool) bool {
+	deadline := time.Now().Add(timeout)
+
+	for time.Now().Before(deadline) {
+		if condition() {
+			return true
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return condition()
+}
+
+// BenchmarkRouteTrieMatch registers a growing number of routes sharing a common shape and matches
+// a path near the middle of the table each time. A trie lookup costs one map/child lookup per
+// path segment, so ns/op should stay roughly flat as routes grows rather than scaling with it
+func BenchmarkRouteTrieMatch(b *testing.B) {
+	for _, routes := range []int{10, 100, 1000, 10000} {
+		b.Run(fmt.Sprintf("routes=%d", routes), func(b *testing.B) {
+			trie := newRouteTrie()
+
+			for i := 0; i < routes; i++ {
+				trie.Method(http.MethodGet,
+					fmt.Sprintf("/bench%d/{id:int}/detail", i),
+					http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+			}
+
+			path := fmt.Sprintf("/bench%d/42/detail", routes/2)
+
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if _, _, ok := trie.match(http.MethodGet, path); !ok {
+					b.Fatal("expected route to match")
+				}
+			}
+		})
+	}
+}
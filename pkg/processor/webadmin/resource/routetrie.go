@@ -0,0 +1,291 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi"
+)
+
+// Params holds the path parameters captured while matching a request against a routeTrie. It is
+// the typed replacement for chi.URLParam: handlers pull their captures from ParamsFromRequest
+// rather than looking them up by name against the router
+type Params map[string]string
+
+// Get returns the named path parameter, or "" if the route didn't capture one by that name
+func (p Params) Get(name string) string {
+	return p[name]
+}
+
+type paramsContextKey struct{}
+
+// ParamsFromRequest returns the path parameters the routeTrie captured while matching request
+func ParamsFromRequest(request *http.Request) Params {
+	params, _ := request.Context().Value(paramsContextKey{}).(Params)
+	return params
+}
+
+// paramKind is the type constraint a {name:kind} path segment declares. A segment with no kind
+// (plain {name}) accepts any non-empty value, matching the historical, untyped chi behavior
+type paramKind int
+
+const (
+	paramKindString paramKind = iota
+	paramKindInt
+	paramKindUUID
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func parseParamKind(kind string) paramKind {
+	switch kind {
+	case "int":
+		return paramKindInt
+	case "uuid":
+		return paramKindUUID
+	default:
+		return paramKindString
+	}
+}
+
+func (k paramKind) matches(value string) bool {
+	switch k {
+	case paramKindInt:
+		_, err := strconv.Atoi(value)
+		return err == nil
+	case paramKindUUID:
+		return uuidPattern.MatchString(value)
+	default:
+		return true
+	}
+}
+
+// routeNode is one segment's worth of a compressed routing trie. Each node holds at most one
+// static-child map, one param child, and one catch-all child, so matching a path descends one
+// node per path segment regardless of how many routes are registered, trying a sibling branch
+// only when the branch it descended has no handler for the method (see match)
+type routeNode struct {
+	static map[string]*routeNode
+
+	param     *routeNode
+	paramName string
+	paramKind paramKind
+
+	catchAll     *routeNode
+	catchAllName string
+
+	handlers map[string]http.Handler
+}
+
+func (n *routeNode) staticChild(segment string) *routeNode {
+	if n.static == nil {
+		n.static = map[string]*routeNode{}
+	}
+
+	child, ok := n.static[segment]
+	if !ok {
+		child = &routeNode{}
+		n.static[segment] = child
+	}
+
+	return child
+}
+
+func (n *routeNode) paramChild(name string, kind paramKind) *routeNode {
+	if n.param == nil {
+		n.param = &routeNode{}
+		n.paramName = name
+		n.paramKind = kind
+	}
+
+	return n.param
+}
+
+func (n *routeNode) catchAllChild(name string) *routeNode {
+	if n.catchAll == nil {
+		n.catchAll = &routeNode{}
+		n.catchAllName = name
+	}
+
+	return n.catchAll
+}
+
+// routeTrie is a chi-router-shaped, trie-backed matcher: static segments, {name}/{name:kind}
+// captures, and *name catch-alls are distinct node kinds, and a lookup descends the tree once per
+// path segment rather than testing every registered route in turn
+type routeTrie struct {
+	root        routeNode
+	middlewares []func(http.Handler) http.Handler
+}
+
+func newRouteTrie() *routeTrie {
+	return &routeTrie{}
+}
+
+func (t *routeTrie) Use(middlewares ...func(http.Handler) http.Handler) {
+	t.middlewares = append(t.middlewares, middlewares...)
+}
+
+func (t *routeTrie) Get(pattern string, handler http.HandlerFunc) {
+	t.Method(http.MethodGet, pattern, handler)
+}
+
+func (t *routeTrie) Post(pattern string, handler http.HandlerFunc) {
+	t.Method(http.MethodPost, pattern, handler)
+}
+
+func (t *routeTrie) Put(pattern string, handler http.HandlerFunc) {
+	t.Method(http.MethodPut, pattern, handler)
+}
+
+func (t *routeTrie) Delete(pattern string, handler http.HandlerFunc) {
+	t.Method(http.MethodDelete, pattern, handler)
+}
+
+// Method inserts handler into the trie at pattern, to be served for the given HTTP method
+func (t *routeTrie) Method(method string, pattern string, handler http.Handler) {
+	node := &t.root
+
+	for _, segment := range splitPath(pattern) {
+		switch {
+		case strings.HasPrefix(segment, "*"):
+			node = node.catchAllChild(strings.TrimPrefix(segment, "*"))
+
+		case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+			name, kind := parseParamSegment(segment)
+			node = node.paramChild(name, kind)
+
+		default:
+			node = node.staticChild(segment)
+		}
+	}
+
+	if node.handlers == nil {
+		node.handlers = map[string]http.Handler{}
+	}
+
+	node.handlers[method] = handler
+}
+
+func parseParamSegment(segment string) (string, paramKind) {
+	name := strings.Trim(segment, "{}")
+
+	if colonIndex := strings.Index(name, ":"); colonIndex >= 0 {
+		return name[:colonIndex], parseParamKind(name[colonIndex+1:])
+	}
+
+	return name, paramKindString
+}
+
+func splitPath(path string) []string {
+	trimmed := strings.Trim(path, "/")
+	if trimmed == "" {
+		return nil
+	}
+
+	return strings.Split(trimmed, "/")
+}
+
+// match descends the trie trying static, then a typed param, then a catch-all at each segment - so
+// the common case is O(len(path)) - but backtracks to the next candidate branch when the one it
+// committed to turns out to have no handler for method at the leaf. Without backtracking, a
+// static sibling that only handles a different method (e.g. a POST-only "/post" route sitting
+// next to a "/{id}" GET route) would incorrectly shadow the param branch for every other method,
+// which is not how the previous per-route chi matcher behaved
+func (t *routeTrie) match(method string, path string) (http.Handler, Params, bool) {
+	segments := splitPath(path)
+
+	handler, params, ok := matchNode(&t.root, method, segments)
+	if !ok {
+		return nil, nil, false
+	}
+
+	return handler, params, true
+}
+
+func matchNode(node *routeNode, method string, segments []string) (http.Handler, Params, bool) {
+	if len(segments) == 0 {
+		handler, ok := node.handlers[method]
+		if !ok {
+			return nil, nil, false
+		}
+
+		return handler, nil, true
+	}
+
+	segment, rest := segments[0], segments[1:]
+
+	if child, ok := node.static[segment]; ok {
+		if handler, params, ok := matchNode(child, method, rest); ok {
+			return handler, params, true
+		}
+	}
+
+	if node.param != nil && node.paramKind.matches(segment) {
+		if handler, params, ok := matchNode(node.param, method, rest); ok {
+			if params == nil {
+				params = Params{}
+			}
+
+			params[node.paramName] = segment
+			return handler, params, true
+		}
+	}
+
+	if node.catchAll != nil {
+		if handler, ok := node.catchAll.handlers[method]; ok {
+			params := Params{node.catchAllName: strings.Join(segments, "/")}
+			return handler, params, true
+		}
+	}
+
+	return nil, nil, false
+}
+
+func (t *routeTrie) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
+	var handler http.Handler = http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		// chi's Mount leaves request.URL.Path untouched and passes the path below the mount point
+		// in the route context's RoutePath, so prefer that when the trie is mounted on a chi router
+		path := request.URL.Path
+		if routeContext := chi.RouteContext(request.Context()); routeContext != nil && routeContext.RoutePath != "" {
+			path = routeContext.RoutePath
+		}
+
+		matchedHandler, params, ok := t.match(request.Method, path)
+		if !ok {
+			http.NotFound(responseWriter, request)
+			return
+		}
+
+		if params != nil {
+			request = request.WithContext(context.WithValue(request.Context(), paramsContextKey{}, params))
+		}
+
+		matchedHandler.ServeHTTP(responseWriter, request)
+	})
+
+	for i := len(t.middlewares) - 1; i >= 0; i-- {
+		handler = t.middlewares[i](handler)
+	}
+
+	handler.ServeHTTP(responseWriter, request)
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Event is a single message pushed down a resource's stream
+type Event struct {
+	ID   string
+	Data interface{}
+}
+
+// streamSendBufferSize bounds the number of events queued per connection before the oldest
+// queued event is dropped to make room for the newest one
+const streamSendBufferSize = 16
+
+// streamHeartbeatInterval is how often an idle stream sends a keep-alive to the client
+const streamHeartbeatInterval = 30 * time.Second
+
+// getStreamResource is implemented by resources that expose a live event stream at
+// GET /{id}/events, served as Server-Sent Events or upgraded to a WebSocket depending on what
+// the client asked for
+type getStreamResource interface {
+	getStream(request *http.Request) (<-chan Event, error)
+}
+
+var websocketUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(request *http.Request) bool { return true },
+}
+
+func (ar *abstractResource) handleStream(responseWriter http.ResponseWriter, request *http.Request) {
+	getStreamResource, ok := ar.resource.(getStreamResource)
+	if !ok {
+		http.NotFound(responseWriter, request)
+		return
+	}
+
+	events, err := getStreamResource.getStream(request)
+	if err != nil {
+		http.Error(responseWriter, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// bound how much a slow consumer can make us buffer, regardless of how the source produces
+	buffered := bufferDropOldest(request.Context(), events, streamSendBufferSize)
+
+	if isWebsocketUpgrade(request) {
+		ar.serveWebsocket(responseWriter, request, buffered)
+		return
+	}
+
+	ar.serveSSE(responseWriter, request, buffered)
+}
+
+func isWebsocketUpgrade(request *http.Request) bool {
+	return strings.EqualFold(request.Header.Get("Upgrade"), "websocket")
+}
+
+// bufferDropOldest re-chans upstream through a bounded buffer: once the buffer is full, the
+// oldest queued event is dropped to make room for the newest one, so a slow consumer never
+// blocks the producer. The returned channel closes when upstream closes or ctx is done
+func bufferDropOldest(ctx context.Context, upstream <-chan Event, capacity int) <-chan Event {
+	out := make(chan Event, capacity)
+
+	go func() {
+		defer close(out)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-upstream:
+				if !ok {
+					return
+				}
+
+				enqueueDropOldest(out, event)
+			}
+		}
+	}()
+
+	return out
+}
+
+func enqueueDropOldest(out chan Event, event Event) {
+	for {
+		select {
+		case out <- event:
+			return
+		default:
+			// buffer is full: make room by dropping whatever is oldest, then retry
+			select {
+			case <-out:
+			default:
+			}
+		}
+	}
+}
+
+// serveSSE streams events as Server-Sent Events until the client disconnects or events closes
+func (ar *abstractResource) serveSSE(responseWriter http.ResponseWriter, request *http.Request, events <-chan Event) {
+	flusher, ok := responseWriter.(http.Flusher)
+	if !ok {
+		http.Error(responseWriter, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	header := responseWriter.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	responseWriter.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-request.Context().Done():
+			return
+
+		case <-heartbeat.C:
+			fmt.Fprint(responseWriter, ": heartbeat\n\n")
+			flusher.Flush()
+
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			encodedData, err := json.Marshal(event.Data)
+			if err != nil {
+				ar.logger.WarnWith("Failed to encode stream event", "error", err)
+				continue
+			}
+
+			fmt.Fprintf(responseWriter, "id: %s\ndata: %s\n\n", event.ID, encodedData)
+			flusher.Flush()
+		}
+	}
+}
+
+// serveWebsocket upgrades the connection and streams events as JSON text frames, with a ping
+// heartbeat, until the client disconnects or events closes
+func (ar *abstractResource) serveWebsocket(responseWriter http.ResponseWriter, request *http.Request, events <-chan Event) {
+	connection, err := websocketUpgrader.Upgrade(responseWriter, request, nil)
+	if err != nil {
+		ar.logger.WarnWith("Failed to upgrade to websocket", "error", err)
+		return
+	}
+
+	defer connection.Close()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-request.Context().Done():
+			return
+
+		case <-heartbeat.C:
+			if err := connection.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			if err := connection.WriteJSON(event); err != nil {
+				return
+			}
+		}
+	}
+}